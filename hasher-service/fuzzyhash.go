@@ -0,0 +1,109 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ==================== FUZZY (CONTEXT-TRIGGERED PIECEWISE) HASHING ====================
+//
+// A pure-Go, simplified implementation of ssdeep's Context Triggered
+// Piecewise Hashing (CTPH): the input is split into variable-length
+// "pieces" wherever a rolling hash hits a trigger value, and each piece
+// is folded into one base64 character. Two inputs that differ by only a
+// handful of bytes still produce mostly-matching signatures, which is
+// what makes this useful for near-duplicate detection (unlike SHA256,
+// which changes completely for a one-byte edit).
+
+const (
+	spamSumLength = 64 // target signature length, same constant ssdeep uses
+	rollingWindow = 7  // bytes considered by the rolling hash at a time
+	minBlockSize  = 3
+)
+
+const base64Alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// rollingState implements ssdeep's rolling hash: a sum of the last
+// rollingWindow bytes, weighted by recency, so the hash changes smoothly
+// as the window slides rather than depending on the whole piece so far.
+type rollingState struct {
+	window     [rollingWindow]byte
+	pos        int
+	h1, h2, h3 uint32 // h1: sum of window, h2: weighted sum, h3: shift-mixed
+}
+
+func (r *rollingState) update(b byte) uint32 {
+	r.h2 -= r.h1
+	r.h2 += uint32(rollingWindow) * uint32(b)
+
+	r.h1 += uint32(b)
+	r.h1 -= uint32(r.window[r.pos])
+
+	r.window[r.pos] = b
+	r.pos = (r.pos + 1) % rollingWindow
+
+	r.h3 = (r.h3 << 5) ^ uint32(b)
+
+	return r.h1 + r.h2 + r.h3
+}
+
+// fnv1aAccumulator folds bytes into a running FNV-1a hash; each piece
+// gets its own accumulator, reset whenever the rolling hash triggers.
+type fnv1aAccumulator uint32
+
+const fnvOffsetBasis fnv1aAccumulator = 2166136261
+const fnvPrime fnv1aAccumulator = 16777619
+
+func (h fnv1aAccumulator) update(b byte) fnv1aAccumulator {
+	h ^= fnv1aAccumulator(b)
+	h *= fnvPrime
+	return h
+}
+
+// blockSizeFor picks the smallest block size such that the input is
+// expected to produce roughly spamSumLength pieces, mirroring ssdeep's
+// adaptive block size selection.
+func blockSizeFor(inputLen int) int {
+	b := minBlockSize
+	for inputLen/b > spamSumLength && b < (1<<24) {
+		b *= 2
+	}
+	return b
+}
+
+// piecewiseSignature runs CTPH over data with the given block size,
+// triggering a new piece whenever the rolling hash modulo blockSize
+// equals blockSize-1, and returns the base64-folded signature.
+func piecewiseSignature(data []byte, blockSize int) string {
+	var sig strings.Builder
+	roll := &rollingState{}
+	acc := fnvOffsetBasis
+
+	for _, b := range data {
+		acc = acc.update(b)
+		h := roll.update(b)
+		if int(h)%blockSize == blockSize-1 {
+			sig.WriteByte(base64Alphabet[uint32(acc)%64])
+			acc = fnvOffsetBasis
+		}
+		if sig.Len() >= spamSumLength {
+			break
+		}
+	}
+	// Son parçayı da ekle (tetiklenmeden biten kuyruk).
+	if sig.Len() < spamSumLength {
+		sig.WriteByte(base64Alphabet[uint32(acc)%64])
+	}
+	return sig.String()
+}
+
+// FuzzyHash computes a ssdeep-style CTPH signature: "blocksize:sig-at-b:sig-at-2b".
+// Comparing two such signatures is the notary service's job (see
+// notary-service/similarity.go), not this one's -- the hasher service
+// never needs to score its own signatures against each other.
+func FuzzyHash(data []byte) string {
+	b := blockSizeFor(len(data))
+	sig1 := piecewiseSignature(data, b)
+	sig2 := piecewiseSignature(data, b*2)
+	return strconv.Itoa(b) + ":" + sig1 + ":" + sig2
+}
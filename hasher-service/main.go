@@ -2,20 +2,48 @@ package main
 
 import (
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 )
 
+// supportedAlgorithms - hashHandler'ın hesaplayabildiği algoritmalar
+var supportedAlgorithms = []string{"sha256", "sha512", "ssdeep"}
+
+// defaultAlgorithms - "algorithms" alanı boş bırakılırsa kullanılan set,
+// eski istemcilerle (yalnızca {"hash": "..."} bekleyen) uyumluluk için
+// response'ta ayrıca "hash" alanı da doldurulur.
+var defaultAlgorithms = []string{"sha256"}
+
 // HashRequest - Frontend'den gelen istek yapısı
 type HashRequest struct {
-	Text string `json:"text"`
+	Text       string   `json:"text"`
+	Algorithms []string `json:"algorithms,omitempty"`
 }
 
 // HashResponse - Frontend'e gönderilecek cevap yapısı
 type HashResponse struct {
-	Hash string `json:"hash"`
+	Hash    string            `json:"hash"` // geriye dönük uyumluluk: sha256 varsa onu, yoksa ilk sonucu taşır
+	Digests map[string]string `json:"digests"`
+}
+
+// computeDigest - tek bir algoritma için text'in hash'ini hesaplar
+func computeDigest(algorithm string, text []byte) (string, error) {
+	switch algorithm {
+	case "sha256":
+		sum := sha256.Sum256(text)
+		return hex.EncodeToString(sum[:]), nil
+	case "sha512":
+		sum := sha512.Sum512(text)
+		return hex.EncodeToString(sum[:]), nil
+	case "ssdeep":
+		return FuzzyHash(text), nil
+	default:
+		return "", fmt.Errorf("unsupported algorithm: %s", algorithm)
+	}
 }
 
 // ErrorResponse - Hata durumunda gönderilecek cevap
@@ -59,13 +87,32 @@ func hashHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// SHA256 hash hesapla
-	hash := sha256.Sum256([]byte(req.Text))
-	hashHex := hex.EncodeToString(hash[:])
+	algorithms := req.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = defaultAlgorithms
+	}
+
+	digests := make(map[string]string, len(algorithms))
+	for _, algorithm := range algorithms {
+		digest, err := computeDigest(algorithm, []byte(req.Text))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+			return
+		}
+		digests[algorithm] = digest
+	}
+
+	// Response gönder - "hash" alanı eski istemciler için sha256'yı taşır
+	resp := HashResponse{Digests: digests}
+	if sha256Digest, ok := digests["sha256"]; ok {
+		resp.Hash = sha256Digest
+	} else {
+		resp.Hash = digests[algorithms[0]]
+	}
 
-	// Response gönder
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(HashResponse{Hash: hashHex})
+	json.NewEncoder(w).Encode(resp)
 }
 
 // healthHandler - Servis sağlık kontrolü
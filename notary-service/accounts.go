@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	jose "github.com/go-jose/go-jose/v4"
+)
+
+// ==================== CLIENT ACCOUNTS ====================
+//
+// Client accounts, modeled on RFC 8555 (ACME): a client proves possession
+// of a keypair once at registration time by self-signing its public JWK,
+// and the server keys the account by that JWK's RFC 7638 thumbprint.
+// Every later request is authenticated by requiring a valid JWS over the
+// same key instead of a bearer token, so there is no secret to steal from
+// the server beyond the (already-public) key itself.
+
+// Account is a registered client key. Its ID is random and stays the same
+// across key rotations; Thumbprint is that of the current JWK.
+type Account struct {
+	ID         string
+	Thumbprint string
+	JWK        jose.JSONWebKey
+}
+
+// AccountStore holds every registered account in memory, keyed both by
+// account ID (used in account URLs) and by JWK thumbprint (used to reject
+// duplicate registrations of the same key).
+type AccountStore struct {
+	mu           sync.RWMutex
+	byID         map[string]*Account
+	byThumbprint map[string]*Account
+}
+
+func NewAccountStore() *AccountStore {
+	return &AccountStore{
+		byID:         make(map[string]*Account),
+		byThumbprint: make(map[string]*Account),
+	}
+}
+
+// Register creates a new account for jwk, or returns the existing one
+// (created=false) if this exact key has already registered.
+func (s *AccountStore) Register(jwk jose.JSONWebKey) (account *Account, created bool, err error) {
+	thumbHex, err := thumbprintHex(jwk)
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.byThumbprint[thumbHex]; ok {
+		return existing, false, nil
+	}
+
+	id, err := randomAccountID()
+	if err != nil {
+		return nil, false, err
+	}
+	account = &Account{ID: id, Thumbprint: thumbHex, JWK: jwk}
+	s.byID[id] = account
+	s.byThumbprint[thumbHex] = account
+	return account, true, nil
+}
+
+// Get looks an account up by its ID (the last path segment of its
+// account URL).
+func (s *AccountStore) Get(id string) (*Account, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	a, ok := s.byID[id]
+	return a, ok
+}
+
+// RotateKey replaces account id's signing key. Callers must have already
+// verified that the caller possesses newJWK's private key (an inner JWS
+// signed by it) before calling this.
+func (s *AccountStore) RotateKey(id string, newJWK jose.JSONWebKey) error {
+	thumbHex, err := thumbprintHex(newJWK)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account, ok := s.byID[id]
+	if !ok {
+		return fmt.Errorf("unknown account %q", id)
+	}
+	if other, exists := s.byThumbprint[thumbHex]; exists && other.ID != id {
+		return fmt.Errorf("key already registered to a different account")
+	}
+
+	delete(s.byThumbprint, account.Thumbprint)
+	account.JWK = newJWK
+	account.Thumbprint = thumbHex
+	s.byThumbprint[thumbHex] = account
+	return nil
+}
+
+func thumbprintHex(jwk jose.JSONWebKey) (string, error) {
+	thumb, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute JWK thumbprint: %v", err)
+	}
+	return hex.EncodeToString(thumb), nil
+}
+
+func randomAccountID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate account id: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
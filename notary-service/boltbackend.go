@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ==================== BOLTDB BACKEND ====================
+//
+// Default single-node Backend: everything lives in one BoltDB file so a
+// restart picks up exactly where the process left off.
+
+var (
+	bucketByHash  = []byte("seals_by_hash")
+	bucketByIndex = []byte("seals_by_index")
+	bucketMeta    = []byte("meta")
+	metaKeyCount  = []byte("count")
+)
+
+// BoltBackend implements Backend on top of a single BoltDB file.
+type BoltBackend struct {
+	mu sync.Mutex
+	db *bolt.DB
+}
+
+// NewBoltBackend opens (creating if necessary) a BoltDB file at path and
+// prepares its buckets.
+func NewBoltBackend(path string) (*BoltBackend, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bolt db at %s: %v", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{bucketByHash, bucketByIndex, bucketMeta} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize bolt buckets: %v", err)
+	}
+	return &BoltBackend{db: db}, nil
+}
+
+func indexKey(index int) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(index))
+	return key
+}
+
+func encodeRecord(r *SealRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeRecord(data []byte) (*SealRecord, error) {
+	var r SealRecord
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// Append assigns the record the next index and durably writes it.
+func (b *BoltBackend) Append(record *SealRecord) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var index int
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(bucketMeta)
+		if count := meta.Get(metaKeyCount); count != nil {
+			index = int(binary.BigEndian.Uint64(count))
+		}
+		record.Index = index
+
+		data, err := encodeRecord(record)
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketByHash).Put([]byte(record.Hash), data); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketByIndex).Put(indexKey(index), []byte(record.Hash)); err != nil {
+			return err
+		}
+		newCount := make([]byte, 8)
+		binary.BigEndian.PutUint64(newCount, uint64(index+1))
+		return meta.Put(metaKeyCount, newCount)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return index, nil
+}
+
+// GetByHash looks a record up by its content hash.
+func (b *BoltBackend) GetByHash(hash string) (*SealRecord, bool, error) {
+	var rec *SealRecord
+	err := b.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketByHash).Get([]byte(hash))
+		if data == nil {
+			return nil
+		}
+		r, err := decodeRecord(data)
+		if err != nil {
+			return err
+		}
+		rec = r
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return rec, rec != nil, nil
+}
+
+// List returns up to limit records starting at offset, in append order.
+// limit <= 0 means "no limit".
+func (b *BoltBackend) List(offset, limit int) ([]*SealRecord, error) {
+	var records []*SealRecord
+	err := b.db.View(func(tx *bolt.Tx) error {
+		idx := tx.Bucket(bucketByIndex)
+		hashes := tx.Bucket(bucketByHash)
+		c := idx.Cursor()
+		i := 0
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if i < offset {
+				i++
+				continue
+			}
+			if limit > 0 && len(records) >= limit {
+				break
+			}
+			data := hashes.Get(v)
+			if data == nil {
+				i++
+				continue
+			}
+			r, err := decodeRecord(data)
+			if err != nil {
+				return err
+			}
+			records = append(records, r)
+			i++
+		}
+		return nil
+	})
+	return records, err
+}
+
+// Snapshot serializes every record currently stored.
+func (b *BoltBackend) Snapshot() ([]byte, error) {
+	all, err := b.List(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return encodeSnapshot(all)
+}
+
+// Restore replaces the store's contents with a previously taken snapshot.
+func (b *BoltBackend) Restore(data []byte) error {
+	records, err := decodeSnapshot(data)
+	if err != nil {
+		return err
+	}
+	return b.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketByHash, bucketByIndex, bucketMeta} {
+			if err := tx.DeleteBucket(name); err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+			if _, err := tx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+		count := make([]byte, 8)
+		for _, r := range records {
+			data, err := encodeRecord(r)
+			if err != nil {
+				return err
+			}
+			if err := tx.Bucket(bucketByHash).Put([]byte(r.Hash), data); err != nil {
+				return err
+			}
+			if err := tx.Bucket(bucketByIndex).Put(indexKey(r.Index), []byte(r.Hash)); err != nil {
+				return err
+			}
+		}
+		binary.BigEndian.PutUint64(count, uint64(len(records)))
+		return tx.Bucket(bucketMeta).Put(metaKeyCount, count)
+	})
+}
+
+// Close releases the underlying BoltDB file handle.
+func (b *BoltBackend) Close() error {
+	return b.db.Close()
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ==================== COMPACT JWS HELPERS ====================
+//
+// Minimal JSON Web Signature (RFC 7515) compact serialization support:
+// header.payload.signature, all base64url-without-padding. The payload
+// here is always the raw seal hash, so the JWS is effectively a signed
+// hash rather than a signature over the full document.
+
+// JWSHeader is the protected header of a seal's JWS.
+type JWSHeader struct {
+	Alg string `json:"alg"` // "RS256" or "ES256"
+	Kid string `json:"kid"` // Vault Transit key name + version, e.g. "seal-signing-key:v3"
+}
+
+func b64url(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func b64urlDecode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+// buildJWS assembles a compact JWS from a header, payload and raw
+// (already computed) signature bytes.
+func buildJWS(header JWSHeader, payload []byte, signature []byte) (string, error) {
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JWS header: %v", err)
+	}
+	return fmt.Sprintf("%s.%s.%s", b64url(headerJSON), b64url(payload), b64url(signature)), nil
+}
+
+// splitJWS parses a compact JWS into its three decoded parts.
+func splitJWS(jws string) (header JWSHeader, payload, signature []byte, err error) {
+	parts := strings.Split(jws, ".")
+	if len(parts) != 3 {
+		return JWSHeader{}, nil, nil, fmt.Errorf("malformed JWS: expected 3 parts, got %d", len(parts))
+	}
+	headerJSON, err := b64urlDecode(parts[0])
+	if err != nil {
+		return JWSHeader{}, nil, nil, fmt.Errorf("invalid JWS header encoding: %v", err)
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return JWSHeader{}, nil, nil, fmt.Errorf("invalid JWS header: %v", err)
+	}
+	payload, err = b64urlDecode(parts[1])
+	if err != nil {
+		return JWSHeader{}, nil, nil, fmt.Errorf("invalid JWS payload encoding: %v", err)
+	}
+	signature, err = b64urlDecode(parts[2])
+	if err != nil {
+		return JWSHeader{}, nil, nil, fmt.Errorf("invalid JWS signature encoding: %v", err)
+	}
+	return header, payload, signature, nil
+}
+
+// JWK is a JSON Web Key as returned by GET /jwks.json (RFC 7517), trimmed
+// down to the fields RS256/ES256/EdDSA verifiers need.
+type JWK struct {
+	Kty string `json:"kty"`           // "RSA", "EC" or "OKP"
+	Use string `json:"use,omitempty"` // "sig"
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`   // RSA modulus, base64url
+	E   string `json:"e,omitempty"`   // RSA public exponent, base64url
+	Crv string `json:"crv,omitempty"` // EC curve, e.g. "P-256"
+	X   string `json:"x,omitempty"`   // EC x coordinate, base64url
+	Y   string `json:"y,omitempty"`   // EC y coordinate, base64url
+}
+
+// JWKSet is the document served at GET /jwks.json.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ==================== KEY MANAGER ====================
+//
+// Seals are signed by a key that never leaves HashiCorp Vault's Transit
+// secrets engine. The notary only ever sends/receives hashes and
+// signatures over Vault's HTTP API; it never holds private key material.
+
+// KeyManager signs seal hashes and exposes the public keys needed to
+// verify those signatures offline.
+type KeyManager interface {
+	// Sign produces a compact JWS over the given seal hash using the
+	// current signing key.
+	Sign(hash []byte) (jws string, err error)
+	// PublicJWKS returns every key version that is still valid for
+	// verification (the current version plus any rotated-out ones).
+	PublicJWKS() (JWKSet, error)
+	// Verify checks a compact JWS against the current JWKS.
+	Verify(jws string) error
+	// Rotate switches signing to the newest Transit key version while
+	// keeping older kids verifiable via PublicJWKS.
+	Rotate() error
+}
+
+// transitKeyInfo mirrors the subset of Vault's
+// GET /v1/transit/keys/{name} response the notary cares about.
+type transitKeyInfo struct {
+	Type          string                     `json:"type"`
+	LatestVersion int                        `json:"latest_version"`
+	Keys          map[string]json.RawMessage `json:"keys"`
+}
+
+type transitKeyVersion struct {
+	PublicKey string `json:"public_key"`
+}
+
+// VaultKeyManager talks to Vault's Transit engine over its HTTP API.
+type VaultKeyManager struct {
+	addr    string
+	token   string
+	keyName string
+	client  *http.Client
+
+	mu            sync.RWMutex
+	latestVersion int // cached latest key version, set at startup and refreshed by Rotate
+}
+
+// NewVaultKeyManager builds a KeyManager authenticated against Vault via
+// VAULT_ADDR/VAULT_TOKEN, or VAULT_ROLE_ID/VAULT_SECRET_ID (AppRole) when
+// VAULT_TOKEN isn't set.
+func NewVaultKeyManager() (*VaultKeyManager, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		addr = "http://127.0.0.1:8200"
+	}
+	keyName := os.Getenv("VAULT_TRANSIT_KEY")
+	if keyName == "" {
+		keyName = "crypto-seal-notary"
+	}
+
+	km := &VaultKeyManager{
+		addr:    strings.TrimSuffix(addr, "/"),
+		keyName: keyName,
+		client:  &http.Client{},
+	}
+
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		roleID := os.Getenv("VAULT_ROLE_ID")
+		secretID := os.Getenv("VAULT_SECRET_ID")
+		if roleID == "" || secretID == "" {
+			return nil, fmt.Errorf("no VAULT_TOKEN and no VAULT_ROLE_ID/VAULT_SECRET_ID set")
+		}
+		t, err := km.approleLogin(roleID, secretID)
+		if err != nil {
+			return nil, err
+		}
+		token = t
+	}
+	km.token = token
+
+	info, err := km.keyInfo()
+	if err != nil {
+		return nil, err
+	}
+	km.latestVersion = info.LatestVersion
+
+	return km, nil
+}
+
+func (km *VaultKeyManager) approleLogin(roleID, secretID string) (string, error) {
+	body, _ := json.Marshal(map[string]string{"role_id": roleID, "secret_id": secretID})
+	resp, err := km.client.Post(km.addr+"/v1/auth/approle/login", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("vault approle login unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("failed to decode vault approle login response: %v", err)
+	}
+	if out.Auth.ClientToken == "" {
+		return "", fmt.Errorf("vault approle login returned no client_token")
+	}
+	return out.Auth.ClientToken, nil
+}
+
+func (km *VaultKeyManager) do(method, path string, body interface{}) (*http.Response, error) {
+	var reader *bytes.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(b)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, km.addr+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", km.token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := km.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault unreachable: %v", err)
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, fmt.Errorf("vault returned status %d for %s %s", resp.StatusCode, method, path)
+	}
+	return resp, nil
+}
+
+func (km *VaultKeyManager) keyInfo() (*transitKeyInfo, error) {
+	resp, err := km.do(http.MethodGet, "/v1/transit/keys/"+km.keyName, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Data transitKeyInfo `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode vault key info: %v", err)
+	}
+	return &out.Data, nil
+}
+
+// algForKeyType maps a Vault Transit key type to the JWS alg that its
+// signatures should be tagged with.
+func algForKeyType(keyType string) (string, error) {
+	switch {
+	case strings.HasPrefix(keyType, "rsa-"):
+		return "RS256", nil
+	case keyType == "ecdsa-p256":
+		return "ES256", nil
+	default:
+		return "", fmt.Errorf("unsupported transit key type for JWS: %s", keyType)
+	}
+}
+
+// Sign signs the given seal hash with the current Transit key version and
+// repackages Vault's signature as a compact JWS.
+func (km *VaultKeyManager) Sign(hash []byte) (string, error) {
+	info, err := km.keyInfo()
+	if err != nil {
+		return "", err
+	}
+	alg, err := algForKeyType(info.Type)
+	if err != nil {
+		return "", err
+	}
+
+	reqBody := map[string]string{
+		"input":                base64.StdEncoding.EncodeToString(hash),
+		"marshaling_algorithm": "jws", // raw r||s for ECDSA instead of ASN.1 DER
+	}
+	resp, err := km.do(http.MethodPost, "/v1/transit/sign/"+km.keyName+"/sha2-256", reqBody)
+	if err != nil {
+		return "", fmt.Errorf("vault sign failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Data struct {
+			Signature string `json:"signature"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("failed to decode vault sign response: %v", err)
+	}
+
+	// Vault signatures look like "vault:v3:<base64 sig>".
+	parts := strings.SplitN(out.Data.Signature, ":", 3)
+	if len(parts) != 3 {
+		return "", fmt.Errorf("unexpected vault signature format: %q", out.Data.Signature)
+	}
+	version := parts[1]
+	sigBytes, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("failed to decode vault signature: %v", err)
+	}
+
+	header := JWSHeader{Alg: alg, Kid: km.keyName + ":" + version}
+	return buildJWS(header, hash, sigBytes)
+}
+
+// PublicJWKS fetches every key version Vault still holds for the
+// configured Transit key and returns them as a JWK Set.
+func (km *VaultKeyManager) PublicJWKS() (JWKSet, error) {
+	info, err := km.keyInfo()
+	if err != nil {
+		return JWKSet{}, err
+	}
+	alg, err := algForKeyType(info.Type)
+	if err != nil {
+		return JWKSet{}, err
+	}
+
+	set := JWKSet{Keys: make([]JWK, 0, len(info.Keys))}
+	for version, raw := range info.Keys {
+		var v transitKeyVersion
+		if err := json.Unmarshal(raw, &v); err != nil {
+			continue
+		}
+		jwk, err := pemToJWK(v.PublicKey, alg, km.keyName+":v"+version)
+		if err != nil {
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
+
+// Rotate asks Vault to generate a new Transit key version and starts
+// signing with it; old versions remain in PublicJWKS so previously
+// issued JWSs stay verifiable.
+func (km *VaultKeyManager) Rotate() error {
+	resp, err := km.do(http.MethodPost, "/v1/transit/keys/"+km.keyName+"/rotate", nil)
+	if err != nil {
+		return fmt.Errorf("vault rotate failed: %v", err)
+	}
+	resp.Body.Close()
+
+	info, err := km.keyInfo()
+	if err != nil {
+		return err
+	}
+	km.mu.Lock()
+	km.latestVersion = info.LatestVersion
+	km.mu.Unlock()
+	return nil
+}
+
+// Verify checks a compact JWS against the current JWKS, without needing
+// to contact Vault's signing endpoint.
+func (km *VaultKeyManager) Verify(jws string) error {
+	set, err := km.PublicJWKS()
+	if err != nil {
+		return err
+	}
+	return verifyJWS(jws, set)
+}
+
+// verifyJWS validates a compact JWS's signature against a JWK Set,
+// matching by kid.
+func verifyJWS(jws string, set JWKSet) error {
+	header, payload, signature, err := splitJWS(jws)
+	if err != nil {
+		return err
+	}
+
+	var match *JWK
+	for i := range set.Keys {
+		if set.Keys[i].Kid == header.Kid {
+			match = &set.Keys[i]
+			break
+		}
+	}
+	if match == nil {
+		return fmt.Errorf("no JWK found for kid %q", header.Kid)
+	}
+
+	digest := sha256.Sum256(payload)
+
+	switch header.Alg {
+	case "RS256":
+		pub, err := rsaPublicKeyFromJWK(*match)
+		if err != nil {
+			return err
+		}
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature)
+	case "ES256":
+		pub, err := ecPublicKeyFromJWK(*match)
+		if err != nil {
+			return err
+		}
+		if len(signature) != 64 {
+			return fmt.Errorf("unexpected ES256 signature length %d", len(signature))
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return fmt.Errorf("ES256 signature verification failed")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported alg %q", header.Alg)
+	}
+}
+
+// pemToJWK parses a PEM-encoded public key (as returned by Vault) into a
+// JWK tagged with the given alg and kid.
+func pemToJWK(pemStr, alg, kid string) (JWK, error) {
+	block, _ := pem.Decode([]byte(pemStr))
+	if block == nil {
+		return JWK{}, fmt.Errorf("failed to decode PEM public key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return JWK{}, fmt.Errorf("failed to parse public key: %v", err)
+	}
+
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: alg,
+			Kid: kid,
+			N:   b64url(key.N.Bytes()),
+			E:   b64url(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		return JWK{
+			Kty: "EC",
+			Use: "sig",
+			Alg: alg,
+			Kid: kid,
+			Crv: "P-256",
+			X:   b64url(key.X.FillBytes(make([]byte, size))),
+			Y:   b64url(key.Y.FillBytes(make([]byte, size))),
+		}, nil
+	default:
+		return JWK{}, fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+func rsaPublicKeyFromJWK(jwk JWK) (*rsa.PublicKey, error) {
+	n, err := b64urlDecode(jwk.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWK modulus: %v", err)
+	}
+	e, err := b64urlDecode(jwk.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWK exponent: %v", err)
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+func ecPublicKeyFromJWK(jwk JWK) (*ecdsa.PublicKey, error) {
+	x, err := b64urlDecode(jwk.X)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWK x coordinate: %v", err)
+	}
+	y, err := b64urlDecode(jwk.Y)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWK y coordinate: %v", err)
+	}
+	return &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}, nil
+}
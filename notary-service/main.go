@@ -2,23 +2,37 @@ package main
 
 import (
 	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	jose "github.com/go-jose/go-jose/v4"
 )
 
 // ==================== MODELS ====================
 
 // SealRecord - Kayıtlı mühür bilgisi
 type SealRecord struct {
-	ID        string    `json:"id"`
-	Hash      string    `json:"hash"`
-	Timestamp time.Time `json:"timestamp"`
-	Text      string    `json:"text,omitempty"` // Opsiyonel: orijinal metin
+	ID        string            `json:"id"`
+	Hash      string            `json:"hash"`
+	Timestamp time.Time         `json:"timestamp"`
+	Text      string            `json:"text,omitempty"`       // Opsiyonel: orijinal metin
+	Index     int               `json:"index"`                // Merkle log leaf index
+	Signature string            `json:"signature,omitempty"`  // Detached JWS over the hash, signed by Vault Transit
+	Digests   map[string]string `json:"digests,omitempty"`    // algorithm -> digest, e.g. "ssdeep" for fuzzy matching
+	AccountID string            `json:"account_id,omitempty"` // account that created this seal; empty for seals issued before accounts existed
 }
 
 // SealRequest - Frontend'den gelen mühür isteği
@@ -32,36 +46,307 @@ type SealResponse struct {
 	Hash      string    `json:"hash"`
 	Timestamp time.Time `json:"timestamp"`
 	Message   string    `json:"message"`
+	Index     int       `json:"index"`
+	TreeSize  int       `json:"tree_size"`
+	RootHash  string    `json:"root_hash"`
+	Signature string    `json:"signature,omitempty"`
+}
+
+// InclusionProofResponse - Bir yaprağın köke kadar olan audit path'i
+type InclusionProofResponse struct {
+	Index     int      `json:"index"`
+	TreeSize  int      `json:"tree_size"`
+	RootHash  string   `json:"root_hash"`
+	AuditPath []string `json:"audit_path"`
+}
+
+// ConsistencyProofResponse - İki tree head arasındaki tutarlılık kanıtı
+type ConsistencyProofResponse struct {
+	First  int      `json:"first"`
+	Second int      `json:"second"`
+	Proof  []string `json:"proof"`
+}
+
+// SignedTreeHead - İmzalı ağaç başı (STH), üçüncü tarafların logu
+// gossip edip çatallanma/geri yazma tespiti yapmasını sağlar.
+type SignedTreeHead struct {
+	TreeSize  int       `json:"tree_size"`
+	RootHash  string    `json:"root_hash"`
+	Timestamp time.Time `json:"timestamp"`
+	Signature string    `json:"signature"` // base64 Ed25519 signature over the above fields
 }
 
 // VerifyRequest - Doğrulama isteği
 type VerifyRequest struct {
 	Text string `json:"text"`
+	JWS  string `json:"jws,omitempty"` // optional: a previously issued JWS to validate offline
+}
+
+// SimilarRequest - /similar isteği gövdesi
+type SimilarRequest struct {
+	Text     string `json:"text"`
+	MinScore int    `json:"min_score,omitempty"`
 }
 
 // VerifyResponse - Doğrulama cevabı
 type VerifyResponse struct {
-	Valid   bool        `json:"valid"`
-	Message string      `json:"message"`
-	Record  *SealRecord `json:"record,omitempty"`
+	Valid        bool           `json:"valid"`
+	Message      string         `json:"message"`
+	Record       *SealRecord    `json:"record,omitempty"`
+	AuditPath    []string       `json:"audit_path,omitempty"`
+	TreeSize     int            `json:"tree_size,omitempty"`
+	JWSValid     *bool          `json:"jws_valid,omitempty"`
+	SimilarSeals []SimilarMatch `json:"similar_seals,omitempty"`
+}
+
+// SimilarMatch - ssdeep benzerlik taramasından dönen tek bir eşleşme
+type SimilarMatch struct {
+	ID    string `json:"id"`
+	Hash  string `json:"hash"`
+	Score int    `json:"score"`
 }
 
+// defaultFuzzySimilarityThreshold - /verify fallback'i ve /similar için varsayılan eşik
+const defaultFuzzySimilarityThreshold = 80
+
 // ErrorResponse - Hata cevabı
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
-// ==================== IN-MEMORY STORE ====================
+// AccountRequest - /accounts ve /accounts/{id}/rotate-key için dış zarf;
+// gövde her zaman tek bir compact JWS string'idir.
+type AccountRequest struct {
+	JWS string `json:"jws"`
+}
+
+// AccountResponse - hesap kaydı/rotasyon cevabı
+type AccountResponse struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// innerKeyRollover - /accounts/{id}/rotate-key'in dış JWS payload'u: yeni
+// anahtarla imzalanmış bir iç JWS taşır, RFC 8555 7.3.5'teki anahtar
+// rotasyonu gibi.
+type innerKeyRollover struct {
+	InnerJWS string `json:"inner_jws"`
+}
+
+// ==================== STORE ====================
 
 type Store struct {
 	mu      sync.RWMutex
-	records map[string]*SealRecord // hash -> record
-	counter int
+	backend Backend    // durable, ordered seal log (BoltDB-backed, optionally Raft-replicated)
+	log     *MerkleLog // transparency log mirroring backend's append order
 }
 
 var store = &Store{
-	records: make(map[string]*SealRecord),
-	counter: 0,
+	log: NewMerkleLog(),
+}
+
+// notLeaderError lets sealHandler tell a Raft "not currently the leader"
+// failure apart from a genuine storage error so it can respond 307
+// instead of 503.
+type notLeaderError struct {
+	leaderHTTPAddr string
+}
+
+func (e *notLeaderError) Error() string {
+	return fmt.Sprintf("not the leader, current leader is %q", e.leaderHTTPAddr)
+}
+
+// sealIDFromIndex derives a seal's display ID from its Merkle log /
+// backend index, since the index (not the ID) is what's actually
+// persisted and replicated.
+func sealIDFromIndex(index int) string {
+	return fmt.Sprintf("SEAL-%06d", index+1)
+}
+
+// findSimilarSeals computes the ssdeep fuzzy hash for the given text and
+// scans every seal belonging to accountID for a fuzzy match scoring at or
+// above minScore.
+func findSimilarSeals(text string, minScore int, accountID string) ([]SimilarMatch, error) {
+	digests, err := getDigestsFromService(text, []string{"ssdeep"})
+	if err != nil {
+		return nil, err
+	}
+	querySig, ok := digests["ssdeep"]
+	if !ok {
+		return nil, fmt.Errorf("hasher service did not return an ssdeep digest")
+	}
+
+	all, err := store.backend.List(0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []SimilarMatch
+	for _, record := range all {
+		if record.AccountID != accountID {
+			continue
+		}
+		sig, ok := record.Digests["ssdeep"]
+		if !ok {
+			continue
+		}
+		score := FuzzySimilarity(querySig, sig)
+		if score >= minScore {
+			matches = append(matches, SimilarMatch{
+				ID:    sealIDFromIndex(record.Index),
+				Hash:  record.Hash,
+				Score: score,
+			})
+		}
+	}
+	return matches, nil
+}
+
+// appendSeal durably appends a record through the configured backend,
+// returning the assigned index. Under Raft, the transparency log is
+// mirrored by sealFSM.Apply (so it stays in lockstep on every node, not
+// just the one that happened to accept the write); in single-node mode
+// there's no FSM, so it's mirrored here instead.
+func appendSeal(record *SealRecord) (int, error) {
+	if rb, ok := store.backend.(*RaftBackend); ok {
+		if !rb.IsLeader() {
+			leaderAddr, _ := rb.LeaderHTTPAddr()
+			return 0, &notLeaderError{leaderHTTPAddr: leaderAddr}
+		}
+		index, err := rb.Append(record)
+		if err != nil {
+			return 0, err
+		}
+		record.Index = index
+		return index, nil
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	index, err := store.backend.Append(record)
+	if err != nil {
+		return 0, err
+	}
+	record.Index = index
+	store.log.Append([]byte(record.Hash))
+	return index, nil
+}
+
+// accountStore holds every registered client account (RFC 8555 style).
+var accountStore = NewAccountStore()
+
+// nonces tracks outstanding, not-yet-used Replay-Nonce values handed out
+// by HEAD /new-nonce.
+var nonces = newNonceCache()
+
+// accountURL builds the account URL a client uses as its JWS "kid" for a
+// given account ID.
+func accountURL(r *http.Request, id string) string {
+	return fmt.Sprintf("http://%s/accounts/%s", r.Host, id)
+}
+
+// accountIDFromKid extracts the trailing account ID from a JWS "kid"
+// (the account URL handed back by POST /accounts).
+func accountIDFromKid(kid string) string {
+	return kid[strings.LastIndex(kid, "/")+1:]
+}
+
+// authenticateRequest verifies a client-signed JWS against its claimed
+// account: the header's kid must resolve to a known account, the nonce
+// must be fresh (issued by HEAD /new-nonce and not yet replayed), and the
+// signature must verify against that account's registered key. On
+// success it returns the account and the JWS's decoded payload.
+func authenticateRequest(r *http.Request) (*Account, []byte, error) {
+	var req AccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, nil, fmt.Errorf("invalid JSON body: %v", err)
+	}
+
+	signed, err := jose.ParseSigned(req.JWS, []jose.SignatureAlgorithm{jose.RS256, jose.ES256, jose.EdDSA})
+	if err != nil {
+		return nil, nil, fmt.Errorf("malformed JWS: %v", err)
+	}
+	header := signed.Signatures[0].Protected
+
+	if header.Nonce == "" || !nonces.consume(header.Nonce) {
+		return nil, nil, fmt.Errorf("missing or already-used nonce")
+	}
+	if header.KeyID == "" {
+		return nil, nil, fmt.Errorf("request JWS is missing kid")
+	}
+
+	account, ok := accountStore.Get(accountIDFromKid(header.KeyID))
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown account %q", header.KeyID)
+	}
+
+	payload, err := signed.Verify(&account.JWK)
+	if err != nil {
+		return nil, nil, fmt.Errorf("JWS signature verification failed: %v", err)
+	}
+	return account, payload, nil
+}
+
+// keyManager signs every issued seal via Vault Transit. It is nil when
+// Vault isn't configured/reachable at startup, in which case seals are
+// issued unsigned (Signature left empty).
+var keyManager KeyManager
+
+// sthSigningKey is the notary's Ed25519 signing key for STHs, persisted
+// under dataDir (see loadOrCreateSTHKey) and published at GET /jwks.json
+// so third parties gossiping STHs have a stable key to check them
+// against across restarts.
+var sthSigningKey ed25519.PrivateKey
+
+const (
+	sthKeyFileName = "sth-key"
+	sthKeyID       = "sth-signing-key"
+)
+
+// loadOrCreateSTHKey reads the Ed25519 seed persisted at
+// dataDir/sth-key, or generates and persists a new one if none exists.
+func loadOrCreateSTHKey(dataDir string) (ed25519.PrivateKey, error) {
+	path := filepath.Join(dataDir, sthKeyFileName)
+	if seed, err := os.ReadFile(path); err == nil {
+		if len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("corrupt STH key file %s: expected %d bytes, got %d", path, ed25519.SeedSize, len(seed))
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read STH key file %s: %v", path, err)
+	}
+
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate STH signing key: %v", err)
+	}
+	if err := os.WriteFile(path, priv.Seed(), 0600); err != nil {
+		return nil, fmt.Errorf("failed to persist STH key to %s: %v", path, err)
+	}
+	return priv, nil
+}
+
+// sthPublicJWK returns the STH signing key's public half as a JWK (RFC
+// 7517/8037 OKP), for GET /jwks.json.
+func sthPublicJWK() JWK {
+	pub := sthSigningKey.Public().(ed25519.PublicKey)
+	return JWK{
+		Kty: "OKP",
+		Use: "sig",
+		Alg: "EdDSA",
+		Kid: sthKeyID,
+		Crv: "Ed25519",
+		X:   base64.RawURLEncoding.EncodeToString(pub),
+	}
+}
+
+// signSTH signs the string "tree_size|root_hash|timestamp" (timestamp in
+// RFC 3339 with nanoseconds) with the notary's Ed25519 key.
+func signSTH(treeSize int, rootHash string, timestamp time.Time) string {
+	msg := fmt.Sprintf("%d|%s|%s", treeSize, rootHash, timestamp.Format(time.RFC3339Nano))
+	sig := ed25519.Sign(sthSigningKey, []byte(msg))
+	return base64.StdEncoding.EncodeToString(sig)
 }
 
 // ==================== HASHER SERVICE CLIENT ====================
@@ -75,36 +360,51 @@ func getHasherServiceURL() string {
 	return url
 }
 
+// sealDigestAlgorithms - her mühür için hasher service'den istenen algoritma seti
+var sealDigestAlgorithms = []string{"sha256", "sha512", "ssdeep"}
+
 // HashRequest - Hasher service'e gönderilen istek
 type HashRequest struct {
-	Text string `json:"text"`
+	Text       string   `json:"text"`
+	Algorithms []string `json:"algorithms,omitempty"`
 }
 
 // HashResponse - Hasher service'den gelen cevap
 type HashResponse struct {
-	Hash string `json:"hash"`
+	Hash    string            `json:"hash"`
+	Digests map[string]string `json:"digests"`
 }
 
-// getHashFromService - Hasher service'den hash alır
+// getHashFromService - Hasher service'den tek bir sha256 hash alır
+// (geriye dönük uyumluluk için korunuyor; yeni kod getDigestsFromService kullanmalı)
 func getHashFromService(text string) (string, error) {
-	reqBody, _ := json.Marshal(HashRequest{Text: text})
+	digests, err := getDigestsFromService(text, []string{"sha256"})
+	if err != nil {
+		return "", err
+	}
+	return digests["sha256"], nil
+}
+
+// getDigestsFromService - Hasher service'den istenen algoritma setinin digest'lerini alır
+func getDigestsFromService(text string, algorithms []string) (map[string]string, error) {
+	reqBody, _ := json.Marshal(HashRequest{Text: text, Algorithms: algorithms})
 
 	resp, err := http.Post(getHasherServiceURL(), "application/json", bytes.NewBuffer(reqBody))
 	if err != nil {
-		return "", fmt.Errorf("hasher service unreachable: %v", err)
+		return nil, fmt.Errorf("hasher service unreachable: %v", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("hasher service returned status: %d", resp.StatusCode)
+		return nil, fmt.Errorf("hasher service returned status: %d", resp.StatusCode)
 	}
 
 	var hashResp HashResponse
 	if err := json.NewDecoder(resp.Body).Decode(&hashResp); err != nil {
-		return "", fmt.Errorf("failed to decode hash response: %v", err)
+		return nil, fmt.Errorf("failed to decode hash response: %v", err)
 	}
 
-	return hashResp.Hash, nil
+	return hashResp.Digests, nil
 }
 
 // ==================== HANDLERS ====================
@@ -132,10 +432,17 @@ func sealHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	account, payload, err := authenticateRequest(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
 	var req SealRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := json.Unmarshal(payload, &req); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON body"})
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON payload"})
 		return
 	}
 
@@ -145,26 +452,70 @@ func sealHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Hasher service'den hash al
-	hash, err := getHashFromService(req.Text)
+	// Hasher service'den tüm digest'leri al (sha256, sha512, ssdeep...)
+	digests, err := getDigestsFromService(req.Text, sealDigestAlgorithms)
 	if err != nil {
 		w.WriteHeader(http.StatusServiceUnavailable)
 		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
 		return
 	}
+	hash, ok := digests["sha256"]
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "hasher service did not return a sha256 digest"})
+		return
+	}
+
+	// Vault Transit üzerinden hash'i imzala (detached JWS)
+	var signature string
+	if keyManager != nil {
+		jws, err := keyManager.Sign([]byte(hash))
+		if err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("signing failed: %v", err)})
+			return
+		}
+		signature = jws
+	}
 
-	// In-memory kaydet
-	store.mu.Lock()
-	store.counter++
 	record := &SealRecord{
-		ID:        fmt.Sprintf("SEAL-%06d", store.counter),
 		Hash:      hash,
 		Timestamp: time.Now().UTC(),
 		Text:      req.Text,
+		Signature: signature,
+		Digests:   digests,
+		AccountID: account.ID,
+	}
+
+	// Backend üzerinden kalıcı olarak ekle (tek düğüm BoltDB ya da Raft cluster)
+	index, err := appendSeal(record)
+	if err != nil {
+		var notLeader *notLeaderError
+		if errors.As(err, &notLeader) {
+			if notLeader.leaderHTTPAddr != "" {
+				w.Header().Set("Location", "http://"+notLeader.leaderHTTPAddr+"/seal")
+			}
+			w.WriteHeader(http.StatusTemporaryRedirect)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: notLeader.Error()})
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
 	}
-	store.records[hash] = record
-	store.mu.Unlock()
+	record.ID = sealIDFromIndex(index)
 
+	// The root as of *this* seal's tree size, not whatever the tree has
+	// grown to by the time we read it -- under concurrent POST /seal
+	// traffic store.log.Root() can already reflect later seals, which
+	// would make the returned root inconsistent with the reported
+	// tree_size/index.
+	root, err := store.log.RootAt(index + 1)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
 	log.Printf("📝 New seal created: %s", record.ID)
 
 	w.WriteHeader(http.StatusCreated)
@@ -173,6 +524,10 @@ func sealHandler(w http.ResponseWriter, r *http.Request) {
 		Hash:      record.Hash,
 		Timestamp: record.Timestamp,
 		Message:   "Document sealed successfully",
+		Index:     index,
+		TreeSize:  index + 1,
+		RootHash:  hex.EncodeToString(root),
+		Signature: record.Signature,
 	})
 }
 
@@ -191,10 +546,17 @@ func verifyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	account, payload, err := authenticateRequest(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
 	var req VerifyRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := json.Unmarshal(payload, &req); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON body"})
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON payload"})
 		return
 	}
 
@@ -212,27 +574,64 @@ func verifyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Store'da ara
-	store.mu.RLock()
-	record, exists := store.records[hash]
-	store.mu.RUnlock()
+	// Backend'de ara
+	record, exists, err := store.backend.GetByHash(hash)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+	// A seal that exists but belongs to a different account must be
+	// treated exactly like a miss -- same scoping /list and /similar
+	// already enforce, otherwise this leaks who else sealed a document.
+	if exists && record.AccountID != account.ID {
+		exists = false
+	}
 
 	if exists {
+		record.ID = sealIDFromIndex(record.Index)
+
+		store.mu.RLock()
+		treeSize := store.log.Size()
+		proof, proofErr := store.log.InclusionProof(record.Index, treeSize)
+		store.mu.RUnlock()
+
+		resp := VerifyResponse{
+			Valid:    true,
+			Message:  "Document verified! This document was sealed.",
+			Record:   record,
+			TreeSize: treeSize,
+		}
+		if proofErr == nil {
+			resp.AuditPath = hashesToHex(proof)
+		}
+
+		// İsteğe bağlı: verilen JWS'i Vault'a hiç dokunmadan, yalnızca
+		// JWKS'e karşı çevrimdışı doğrula.
+		if req.JWS != "" && keyManager != nil {
+			valid := keyManager.Verify(req.JWS) == nil
+			resp.JWSValid = &valid
+		}
+
 		log.Printf("✅ Verification successful: %s", record.ID)
 		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(VerifyResponse{
-			Valid:   true,
-			Message: "Document verified! This document was sealed.",
-			Record:  record,
-		})
+		json.NewEncoder(w).Encode(resp)
 	} else {
-		log.Printf("❌ Verification failed: hash not found")
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(VerifyResponse{
+		resp := VerifyResponse{
 			Valid:   false,
 			Message: "Document not found. This document was never sealed or has been modified.",
 			Record:  nil,
-		})
+		}
+
+		// Tam eşleşme yoksa ssdeep ile yakın-kopya taraması yap.
+		if similar, simErr := findSimilarSeals(req.Text, defaultFuzzySimilarityThreshold, account.ID); simErr == nil && len(similar) > 0 {
+			resp.SimilarSeals = similar
+			resp.Message = fmt.Sprintf("Document not found, but it is a near-duplicate of %d previously sealed document(s).", len(similar))
+		}
+
+		log.Printf("❌ Verification failed: hash not found")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
 	}
 }
 
@@ -245,24 +644,40 @@ func listHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if r.Method != http.MethodGet {
+	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Only GET method is allowed"})
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Only POST method is allowed (POST-as-GET, RFC 8555 style, so the request can be authenticated)"})
 		return
 	}
 
-	store.mu.RLock()
-	records := make([]*SealRecord, 0, len(store.records))
-	for _, record := range store.records {
+	account, _, err := authenticateRequest(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	all, err := store.backend.List(0, 0)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	records := make([]*SealRecord, 0, len(all))
+	for _, record := range all {
+		if record.AccountID != account.ID {
+			continue
+		}
 		// Text'i gizle (güvenlik için)
 		recordCopy := &SealRecord{
-			ID:        record.ID,
+			ID:        sealIDFromIndex(record.Index),
 			Hash:      record.Hash,
 			Timestamp: record.Timestamp,
+			Index:     record.Index,
 		}
 		records = append(records, recordCopy)
 	}
-	store.mu.RUnlock()
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -274,26 +689,617 @@ func listHandler(w http.ResponseWriter, r *http.Request) {
 // healthHandler - Servis sağlık kontrolü
 func healthHandler(w http.ResponseWriter, r *http.Request) {
 	setCORSHeaders(w)
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{
+	resp := map[string]interface{}{
 		"status":  "healthy",
 		"service": "notary-service",
-	})
+	}
+	if rb, ok := store.backend.(*RaftBackend); ok {
+		status := rb.Status()
+		resp["leader"] = status.Leader
+		resp["raft_state"] = status.RaftState
+		resp["last_applied_index"] = status.LastAppliedIndex
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
 }
 
-func main() {
-	// Routes
-	http.HandleFunc("/seal", sealHandler)
-	http.HandleFunc("/verify", verifyHandler)
-	http.HandleFunc("/list", listHandler)
-	http.HandleFunc("/health", healthHandler)
+// clusterJoinHandler - POST /cluster/join: bir düğümü Raft cluster'ına oy hakkıyla ekler (yalnızca lider uygular)
+func clusterJoinHandler(w http.ResponseWriter, r *http.Request) {
+	setCORSHeaders(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Only POST method is allowed"})
+		return
+	}
+	rb, ok := store.backend.(*RaftBackend)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "this node is not running in Raft cluster mode"})
+		return
+	}
+
+	var req struct {
+		NodeID   string `json:"node_id"`
+		RaftAddr string `json:"raft_addr"`
+		HTTPAddr string `json:"http_addr"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON body"})
+		return
+	}
+
+	if err := rb.AddVoter(req.NodeID, req.RaftAddr, req.HTTPAddr); err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Printf("🤝 Node %s (%s) joined the cluster", req.NodeID, req.RaftAddr)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "joined"})
+}
+
+// clusterRemoveHandler - POST /cluster/remove: bir düğümü Raft cluster'ından çıkarır
+func clusterRemoveHandler(w http.ResponseWriter, r *http.Request) {
+	setCORSHeaders(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Only POST method is allowed"})
+		return
+	}
+	rb, ok := store.backend.(*RaftBackend)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "this node is not running in Raft cluster mode"})
+		return
+	}
+
+	var req struct {
+		NodeID string `json:"node_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON body"})
+		return
+	}
+
+	if err := rb.RemoveServer(req.NodeID); err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Printf("👋 Node %s removed from the cluster", req.NodeID)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "removed"})
+}
+
+// clusterStatusHandler - GET /cluster/status: bu düğümün Raft durumunu döner
+func clusterStatusHandler(w http.ResponseWriter, r *http.Request) {
+	setCORSHeaders(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Only GET method is allowed"})
+		return
+	}
+	rb, ok := store.backend.(*RaftBackend)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "this node is not running in Raft cluster mode"})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(rb.Status())
+}
+
+// similarHandler - POST /similar (POST-as-GET, RFC 8555 style, so the
+// request can be authenticated): ssdeep benzerlik skoru min_score'a
+// (varsayılan 80) ulaşan, çağıran hesaba ait mühürleri sırasız döner
+func similarHandler(w http.ResponseWriter, r *http.Request) {
+	setCORSHeaders(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Only POST method is allowed (POST-as-GET, RFC 8555 style, so the request can be authenticated)"})
+		return
+	}
+
+	account, payload, err := authenticateRequest(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var req SimilarRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+	if req.Text == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "text field is required"})
+		return
+	}
+
+	minScore := defaultFuzzySimilarityThreshold
+	if req.MinScore != 0 {
+		minScore = req.MinScore
+	}
+
+	matches, err := findSimilarSeals(req.Text, minScore, account.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"count":   len(matches),
+		"matches": matches,
+	})
+}
+
+// jwksHandler - GET /jwks.json: STH imzalama anahtarını, ve varsa Vault
+// Transit'teki mevcut + eski seal imzalama anahtar sürümlerini JWKS
+// olarak döner
+func jwksHandler(w http.ResponseWriter, r *http.Request) {
+	setCORSHeaders(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Only GET method is allowed"})
+		return
+	}
+
+	jwks := JWKSet{Keys: []JWK{sthPublicJWK()}}
+	if keyManager != nil {
+		sealJWKS, err := keyManager.PublicJWKS()
+		if err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+			return
+		}
+		jwks.Keys = append(jwks.Keys, sealJWKS.Keys...)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(jwks)
+}
+
+// rotateKeyHandler - POST /keys/rotate: Vault Transit anahtarını yeni bir sürüme döndürür
+func rotateKeyHandler(w http.ResponseWriter, r *http.Request) {
+	setCORSHeaders(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Only POST method is allowed"})
+		return
+	}
+	if keyManager == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "key manager not configured"})
+		return
+	}
+
+	if err := keyManager.Rotate(); err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Printf("🔑 Vault Transit key rotated")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "key rotated"})
+}
+
+// newNonceHandler - HEAD /new-nonce: istemcinin bir sonraki imzalı isteği
+// için kullanacağı tek kullanımlık nonce'u Replay-Nonce header'ında döner.
+func newNonceHandler(w http.ResponseWriter, r *http.Request) {
+	setCORSHeaders(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodHead && r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Only HEAD (or GET) is allowed"})
+		return
+	}
+
+	nonce, err := nonces.issue()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Replay-Nonce", nonce)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// accountsHandler - POST /accounts: RFC 8555 tarzı hesap kaydı. İstemci
+// public JWK'sini JWS'in korumalı başlığına ("jwk") gömerek kendi
+// anahtarıyla imzalar; sunucu hesabı bu JWK'nin RFC 7638 thumbprint'iyle
+// eşler, hesap kimliği (ID) ise rastgele üretilir. Aynı anahtarla tekrar
+// kayıt, mevcut hesabı döner.
+func accountsHandler(w http.ResponseWriter, r *http.Request) {
+	setCORSHeaders(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Only POST method is allowed"})
+		return
+	}
+
+	var req AccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON body"})
+		return
+	}
+
+	signed, err := jose.ParseSigned(req.JWS, []jose.SignatureAlgorithm{jose.RS256, jose.ES256, jose.EdDSA})
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("malformed JWS: %v", err)})
+		return
+	}
+	header := signed.Signatures[0].Protected
+	if header.JSONWebKey == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: `account registration JWS must embed the public key in its "jwk" header`})
+		return
+	}
+	if header.Nonce == "" || !nonces.consume(header.Nonce) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "missing or already-used nonce"})
+		return
+	}
+	if _, err := signed.Verify(header.JSONWebKey); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("JWS signature verification failed: %v", err)})
+		return
+	}
+
+	account, created, err := accountStore.Register(*header.JSONWebKey)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+		log.Printf("👤 New account registered: %s", account.ID)
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(AccountResponse{ID: account.ID, URL: accountURL(r, account.ID)})
+}
+
+// rotateAccountKeyHandler - POST /accounts/{id}/rotate-key: bir hesabın
+// imzalama anahtarını değiştirir. RFC 8555 7.3.5'teki gibi, mevcut anahtarla
+// imzalı dış JWS'in payload'u yeni anahtarla imzalı bir iç JWS taşır; yeni
+// anahtara sahip olduğu kanıtlanmadan rotasyon kabul edilmez.
+func rotateAccountKeyHandler(w http.ResponseWriter, r *http.Request) {
+	setCORSHeaders(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if !strings.HasSuffix(r.URL.Path, "/rotate-key") {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "not found"})
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Only POST method is allowed"})
+		return
+	}
+
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/accounts/"), "/rotate-key")
+
+	account, outerPayload, err := authenticateRequest(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+	if account.ID != id {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "JWS is authenticated as a different account"})
+		return
+	}
+
+	var rollover innerKeyRollover
+	if err := json.Unmarshal(outerPayload, &rollover); err != nil || rollover.InnerJWS == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "payload must carry an inner_jws signed by the new key"})
+		return
+	}
+
+	innerSigned, err := jose.ParseSigned(rollover.InnerJWS, []jose.SignatureAlgorithm{jose.RS256, jose.ES256, jose.EdDSA})
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("malformed inner JWS: %v", err)})
+		return
+	}
+	innerHeader := innerSigned.Signatures[0].Protected
+	if innerHeader.JSONWebKey == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: `inner JWS must embed the new public key in its "jwk" header`})
+		return
+	}
+	if _, err := innerSigned.Verify(innerHeader.JSONWebKey); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("inner JWS signature verification failed: %v", err)})
+		return
+	}
+
+	if err := accountStore.RotateKey(account.ID, *innerHeader.JSONWebKey); err != nil {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Printf("🔁 Account %s rotated its signing key", account.ID)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "key rotated"})
+}
+
+// hashesToHex - hash dizisini hex string dizisine çevirir
+func hashesToHex(hashes [][]byte) []string {
+	out := make([]string, len(hashes))
+	for i, h := range hashes {
+		out[i] = hex.EncodeToString(h)
+	}
+	return out
+}
+
+// proofHandler - GET /proof/{index}?tree_size=N: bir yaprağın audit path'ini döner
+func proofHandler(w http.ResponseWriter, r *http.Request) {
+	setCORSHeaders(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Only GET method is allowed"})
+		return
+	}
+
+	indexStr := strings.TrimPrefix(r.URL.Path, "/proof/")
+	index, err := strconv.Atoi(indexStr)
+	if err != nil || index < 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid leaf index"})
+		return
+	}
+
+	store.mu.RLock()
+	treeSize := store.log.Size()
+	store.mu.RUnlock()
+
+	if ts := r.URL.Query().Get("tree_size"); ts != "" {
+		parsed, err := strconv.Atoi(ts)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid tree_size"})
+			return
+		}
+		treeSize = parsed
+	}
+
+	proof, err := store.log.InclusionProof(index, treeSize)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+	root, err := store.log.RootAt(treeSize)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(InclusionProofResponse{
+		Index:     index,
+		TreeSize:  treeSize,
+		RootHash:  hex.EncodeToString(root),
+		AuditPath: hashesToHex(proof),
+	})
+}
+
+// consistencyHandler - GET /consistency?first=M&second=N: iki tree head arasındaki CT tutarlılık kanıtını döner
+func consistencyHandler(w http.ResponseWriter, r *http.Request) {
+	setCORSHeaders(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Only GET method is allowed"})
+		return
+	}
+
+	first, errFirst := strconv.Atoi(r.URL.Query().Get("first"))
+	second, errSecond := strconv.Atoi(r.URL.Query().Get("second"))
+	if errFirst != nil || errSecond != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "first and second must be integers"})
+		return
+	}
+
+	proof, err := store.log.ConsistencyProof(first, second)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ConsistencyProofResponse{
+		First:  first,
+		Second: second,
+		Proof:  hashesToHex(proof),
+	})
+}
+
+// sthHandler - GET /sth: imzalı ağaç başını (signed tree head) döner
+func sthHandler(w http.ResponseWriter, r *http.Request) {
+	setCORSHeaders(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Only GET method is allowed"})
+		return
+	}
+
+	store.mu.RLock()
+	treeSize := store.log.Size()
+	root := store.log.Root()
+	store.mu.RUnlock()
+
+	rootHex := hex.EncodeToString(root)
+	timestamp := time.Now().UTC()
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(SignedTreeHead{
+		TreeSize:  treeSize,
+		RootHash:  rootHex,
+		Timestamp: timestamp,
+		Signature: signSTH(treeSize, rootHex, timestamp),
+	})
+}
+
+func main() {
+	nodeID := flag.String("node-id", "", "unique Raft node id; enables Raft cluster mode when set")
+	raftAddr := flag.String("raft-addr", "127.0.0.1:7000", "address this node's Raft transport binds to")
+	httpAddr := flag.String("http-addr", "127.0.0.1:8082", "this node's own HTTP address, advertised to peers for leader forwarding")
+	join := flag.String("join", "", "HTTP address of an existing cluster member to join")
+	dataDir := flag.String("data-dir", "./notary-data", "directory for the BoltDB seal store and (if clustered) the Raft log/snapshots")
+	flag.Parse()
+
+	var err error
+	if *nodeID != "" {
+		store.backend, err = NewRaftBackend(RaftConfig{
+			NodeID:   *nodeID,
+			RaftAddr: *raftAddr,
+			HTTPAddr: *httpAddr,
+			DataDir:  *dataDir,
+			Join:     *join,
+		}, store.log)
+		if err != nil {
+			log.Fatalf("Failed to start raft backend: %v", err)
+		}
+		log.Printf("🗳️  Raft node %s listening on %s (data dir %s)", *nodeID, *raftAddr, *dataDir)
+		// sealFSM.Apply replays every committed entry (including the
+		// catch-up replay raft does on startup) through store.log itself,
+		// so the transparency log is already in lockstep with this node's
+		// BoltDB contents -- unlike single-node mode below, there's no
+		// separate bootstrap step to do here.
+	} else {
+		if err := os.MkdirAll(*dataDir, 0755); err != nil {
+			log.Fatalf("Failed to create data dir: %v", err)
+		}
+		store.backend, err = NewBoltBackend(filepath.Join(*dataDir, "seals.db"))
+		if err != nil {
+			log.Fatalf("Failed to open bolt backend: %v", err)
+		}
+
+		// Restart sonrası Merkle log'u kalıcı depodan yeniden kur
+		existing, err := store.backend.List(0, 0)
+		if err != nil {
+			log.Fatalf("Failed to load existing seals: %v", err)
+		}
+		for _, record := range existing {
+			store.log.Append([]byte(record.Hash))
+		}
+		log.Printf("📚 Restored %d seals into the transparency log", len(existing))
+	}
+
+	sthSigningKey, err = loadOrCreateSTHKey(*dataDir)
+	if err != nil {
+		log.Fatalf("Failed to load STH signing key: %v", err)
+	}
+
+	// Routes
+	http.HandleFunc("/seal", sealHandler)
+	http.HandleFunc("/verify", verifyHandler)
+	http.HandleFunc("/list", listHandler)
+	http.HandleFunc("/health", healthHandler)
+	http.HandleFunc("/proof/", proofHandler)
+	http.HandleFunc("/consistency", consistencyHandler)
+	http.HandleFunc("/sth", sthHandler)
+	http.HandleFunc("/jwks.json", jwksHandler)
+	http.HandleFunc("/keys/rotate", rotateKeyHandler)
+	http.HandleFunc("/cluster/join", clusterJoinHandler)
+	http.HandleFunc("/cluster/remove", clusterRemoveHandler)
+	http.HandleFunc("/cluster/status", clusterStatusHandler)
+	http.HandleFunc("/similar", similarHandler)
+	http.HandleFunc("/accounts", accountsHandler)
+	http.HandleFunc("/accounts/", rotateAccountKeyHandler)
+	http.HandleFunc("/new-nonce", newNonceHandler)
+
+	if km, err := NewVaultKeyManager(); err != nil {
+		log.Printf("⚠️  Vault Transit not configured, seals will be unsigned: %v", err)
+	} else {
+		keyManager = km
+		log.Printf("🔑 Signing seals via Vault Transit key %q", km.keyName)
+	}
 
-	port := ":8082"
-	log.Printf("📜 Notary Service starting on port %s", port)
-	log.Printf("📍 Endpoints: POST /seal, POST /verify, GET /list, GET /health")
+	log.Printf("📜 Notary Service starting on %s", *httpAddr)
+	log.Printf("📍 Endpoints: POST /seal, POST /verify, POST /list, GET /health, GET /proof/{index}, GET /consistency, GET /sth, GET /jwks.json, POST /keys/rotate, POST /cluster/join, POST /cluster/remove, GET /cluster/status, POST /similar, POST /accounts, POST /accounts/{id}/rotate-key, HEAD /new-nonce")
 	log.Printf("🔗 Hasher Service: %s", getHasherServiceURL())
 
-	if err := http.ListenAndServe(port, nil); err != nil {
+	if err := http.ListenAndServe(*httpAddr, nil); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
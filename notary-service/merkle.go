@@ -0,0 +1,224 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+)
+
+// ==================== MERKLE TRANSPARENCY LOG ====================
+//
+// Append-only log following RFC 6962 (Certificate Transparency) hashing
+// rules: leaf hashes are domain-separated with a 0x00 prefix and internal
+// node hashes with a 0x01 prefix, which prevents an attacker from using a
+// leaf hash as a forged internal node (the classic second-preimage attack
+// on naive Merkle trees).
+
+const (
+	leafHashPrefix = 0x00
+	nodeHashPrefix = 0x01
+)
+
+func leafHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{leafHashPrefix})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func nodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{nodeHashPrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// emptyRoot is the root hash of a tree with zero leaves (SHA256 of the
+// empty string, per RFC 6962).
+func emptyRoot() []byte {
+	h := sha256.Sum256(nil)
+	return h[:]
+}
+
+// largestPowerOfTwoLessThan returns the largest power of two strictly
+// less than n (n must be > 1).
+func largestPowerOfTwoLessThan(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// MerkleLog is an append-only Merkle tree log. It keeps every leaf hash
+// so that inclusion and consistency proofs can be recomputed against any
+// historical tree size, and caches the right-spine of "perfect" subtree
+// roots so that appending a leaf and recomputing the current root only
+// costs O(log N) hash operations instead of rehashing the whole tree.
+type MerkleLog struct {
+	mu     sync.RWMutex
+	leaves [][]byte       // leaf hashes, in append order
+	spine  map[int][]byte // level -> root of the complete subtree ending at the current size
+}
+
+func NewMerkleLog() *MerkleLog {
+	return &MerkleLog{spine: make(map[int][]byte)}
+}
+
+// Append adds a new leaf (the raw record bytes, not yet hashed) to the
+// log and returns its index and the new tree root.
+func (m *MerkleLog) Append(data []byte) (index int, root []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hash := leafHash(data)
+	index = len(m.leaves)
+	m.leaves = append(m.leaves, hash)
+
+	// Standard binary-counter merge: the new leaf is a level-0 "carry"
+	// that merges with any existing complete subtree at the same level,
+	// propagating upward until it lands on a free level.
+	carry := hash
+	level := 0
+	for {
+		existing, ok := m.spine[level]
+		if !ok {
+			break
+		}
+		carry = nodeHash(existing, carry)
+		delete(m.spine, level)
+		level++
+	}
+	m.spine[level] = carry
+
+	return index, m.rootLocked()
+}
+
+func (m *MerkleLog) rootLocked() []byte {
+	if len(m.leaves) == 0 {
+		return emptyRoot()
+	}
+	// Fold the spine's perfect-subtree peaks from the lowest level
+	// (rightmost, smallest subtree) up to the highest (leftmost), since
+	// RFC 6962's MTH nests every smaller subtree under the right child.
+	maxLevel := 0
+	for level := range m.spine {
+		if level > maxLevel {
+			maxLevel = level
+		}
+	}
+	var root []byte
+	for level := 0; level <= maxLevel; level++ {
+		peak, ok := m.spine[level]
+		if !ok {
+			continue
+		}
+		if root == nil {
+			root = peak
+		} else {
+			root = nodeHash(peak, root)
+		}
+	}
+	return root
+}
+
+// Root returns the current tree head hash.
+func (m *MerkleLog) Root() []byte {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.rootLocked()
+}
+
+// Size returns the current number of leaves.
+func (m *MerkleLog) Size() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.leaves)
+}
+
+// RootAt recomputes MTH(D[0:n]) for a historical tree size n, per RFC
+// 6962 section 2.1.
+func (m *MerkleLog) RootAt(n int) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if n < 0 || n > len(m.leaves) {
+		return nil, fmt.Errorf("tree_size %d out of range [0,%d]", n, len(m.leaves))
+	}
+	return mth(m.leaves[:n]), nil
+}
+
+// mth implements RFC 6962's MTH(D[n]) over already-hashed leaves.
+func mth(leaves [][]byte) []byte {
+	n := len(leaves)
+	if n == 0 {
+		return emptyRoot()
+	}
+	if n == 1 {
+		return leaves[0]
+	}
+	k := largestPowerOfTwoLessThan(n)
+	left := mth(leaves[:k])
+	right := mth(leaves[k:])
+	return nodeHash(left, right)
+}
+
+// InclusionProof returns the audit path (RFC 6962 PATH(m, D[n])) proving
+// that the leaf at index m is included in the tree of the given
+// historical size.
+func (m *MerkleLog) InclusionProof(index, treeSize int) ([][]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if treeSize < 0 || treeSize > len(m.leaves) {
+		return nil, fmt.Errorf("tree_size %d out of range [0,%d]", treeSize, len(m.leaves))
+	}
+	if index < 0 || index >= treeSize {
+		return nil, fmt.Errorf("index %d out of range [0,%d)", index, treeSize)
+	}
+	return path(index, m.leaves[:treeSize]), nil
+}
+
+// path implements RFC 6962's PATH(m, D[n]).
+func path(m int, leaves [][]byte) [][]byte {
+	n := len(leaves)
+	if n <= 1 {
+		return nil
+	}
+	k := largestPowerOfTwoLessThan(n)
+	if m < k {
+		return append(path(m, leaves[:k]), mth(leaves[k:]))
+	}
+	return append(path(m-k, leaves[k:]), mth(leaves[:k]))
+}
+
+// ConsistencyProof returns the RFC 6962 consistency proof PROOF(first,
+// D[second]) showing that the tree of size `second` is an append-only
+// extension of the tree of size `first`.
+func (m *MerkleLog) ConsistencyProof(first, second int) ([][]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if first < 0 || second > len(m.leaves) || first > second {
+		return nil, fmt.Errorf("invalid range first=%d second=%d (log size %d)", first, second, len(m.leaves))
+	}
+	if first == 0 || first == second {
+		return [][]byte{}, nil
+	}
+	return subProof(first, m.leaves[:second], true), nil
+}
+
+// subProof implements RFC 6962's SUBPROOF(m, D[n], b).
+func subProof(m int, leaves [][]byte, b bool) [][]byte {
+	n := len(leaves)
+	if m == n {
+		if b {
+			return [][]byte{}
+		}
+		return [][]byte{mth(leaves)}
+	}
+	k := largestPowerOfTwoLessThan(n)
+	if m <= k {
+		return append(subProof(m, leaves[:k], b), mth(leaves[k:]))
+	}
+	proof := subProof(m-k, leaves[k:], false)
+	return append(proof, mth(leaves[:k]))
+}
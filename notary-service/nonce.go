@@ -0,0 +1,65 @@
+package main
+
+import (
+	"container/list"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"sync"
+)
+
+// ==================== REPLAY-NONCE CACHE ====================
+//
+// RFC 8555 style anti-replay: every authenticated request must carry a
+// nonce freshly issued by HEAD /new-nonce, and each nonce is usable
+// exactly once. The cache is bounded so flooding /new-nonce can't grow it
+// without limit; the oldest still-unused nonce is evicted first.
+
+const nonceCacheCapacity = 10000
+
+type nonceCache struct {
+	mu       sync.Mutex
+	order    *list.List
+	elements map[string]*list.Element
+}
+
+func newNonceCache() *nonceCache {
+	return &nonceCache{
+		order:    list.New(),
+		elements: make(map[string]*list.Element),
+	}
+}
+
+// issue generates a fresh nonce and remembers it as outstanding/unused.
+func (c *nonceCache) issue() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	nonce := base64.RawURLEncoding.EncodeToString(raw)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem := c.order.PushBack(nonce)
+	c.elements[nonce] = elem
+	if c.order.Len() > nonceCacheCapacity {
+		oldest := c.order.Front()
+		c.order.Remove(oldest)
+		delete(c.elements, oldest.Value.(string))
+	}
+	return nonce, nil
+}
+
+// consume reports whether nonce was actually issued and hasn't been used
+// before, removing it either way so it can never be replayed.
+func (c *nonceCache) consume(nonce string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.elements[nonce]
+	if !ok {
+		return false
+	}
+	c.order.Remove(elem)
+	delete(c.elements, nonce)
+	return true
+}
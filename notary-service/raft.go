@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+	raftboltdb "github.com/hashicorp/raft-boltdb/v2"
+)
+
+// ==================== RAFT-REPLICATED BACKEND ====================
+//
+// RaftBackend wraps a local BoltBackend with hashicorp/raft so that
+// every node in a cluster applies seals in the same order, and a
+// restarted/crashed node can catch back up from the Raft log + snapshots
+// instead of losing its state.
+
+// sealCommand is the payload of a single Raft log entry.
+type sealCommand struct {
+	Record *SealRecord
+}
+
+// sealFSM applies committed Raft log entries to a local Backend. It also
+// mirrors each entry into the Merkle transparency log here, rather than
+// leaving that to the leader's HTTP handler, so that every node's log
+// (including a follower that's never been leader, or a new leader after
+// failover) advances in lockstep with its replicated BoltDB contents
+// instead of only ever reflecting whichever node happened to accept the
+// original write.
+type sealFSM struct {
+	local *BoltBackend
+	log   *MerkleLog
+}
+
+// Apply decodes and applies one committed log entry, returning the
+// assigned index (or an error) as the raft.ApplyFuture's Response().
+func (f *sealFSM) Apply(l *raft.Log) interface{} {
+	var cmd sealCommand
+	if err := gob.NewDecoder(bytes.NewReader(l.Data)).Decode(&cmd); err != nil {
+		return fmt.Errorf("failed to decode raft log entry: %v", err)
+	}
+	index, err := f.local.Append(cmd.Record)
+	if err != nil {
+		return err
+	}
+	cmd.Record.Index = index
+	f.log.Append([]byte(cmd.Record.Hash))
+	return index
+}
+
+type sealFSMSnapshot struct {
+	data []byte
+}
+
+func (s *sealFSMSnapshot) Persist(sink raft.SnapshotSink) error {
+	if _, err := sink.Write(s.data); err != nil {
+		sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+func (s *sealFSMSnapshot) Release() {}
+
+func (f *sealFSM) Snapshot() (raft.FSMSnapshot, error) {
+	data, err := f.local.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &sealFSMSnapshot{data: data}, nil
+}
+
+func (f *sealFSM) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+	return f.local.Restore(data)
+}
+
+// RaftBackend is a Backend whose writes go through Raft consensus; reads
+// are served from the local replica for simplicity (eventually
+// consistent on followers, strongly consistent on the leader).
+type RaftBackend struct {
+	nodeID   string
+	raftAddr string
+	httpAddr string
+
+	local *BoltBackend
+	fsm   *sealFSM
+	raft  *raft.Raft
+
+	mu       sync.RWMutex
+	peerHTTP map[string]string // raft server address -> HTTP address, used to forward/redirect writes to the leader
+}
+
+// RaftConfig carries the notary binary's --node-id/--raft-addr/--join
+// flags through to RaftBackend setup.
+type RaftConfig struct {
+	NodeID   string
+	RaftAddr string
+	HTTPAddr string
+	DataDir  string
+	Join     string // HTTP address of an existing cluster member, empty to bootstrap a new cluster
+}
+
+// NewRaftBackend starts (or rejoins) a Raft node backed by BoltDB, both
+// for the FSM state and for the Raft log/stable stores. log is the
+// transparency log to keep in sync with the FSM's applied entries; raft
+// replays committed entries through Apply on every node (including a
+// catch-up replay on restart), so log ends up rebuilt identically
+// everywhere without any separate bootstrap step.
+func NewRaftBackend(cfg RaftConfig, log *MerkleLog) (*RaftBackend, error) {
+	if err := os.MkdirAll(cfg.DataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create raft data dir: %v", err)
+	}
+
+	local, err := NewBoltBackend(filepath.Join(cfg.DataDir, "seals.db"))
+	if err != nil {
+		return nil, err
+	}
+	fsm := &sealFSM{local: local, log: log}
+
+	raftConfig := raft.DefaultConfig()
+	raftConfig.LocalID = raft.ServerID(cfg.NodeID)
+
+	addr, err := net.ResolveTCPAddr("tcp", cfg.RaftAddr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid raft address %q: %v", cfg.RaftAddr, err)
+	}
+	transport, err := raft.NewTCPTransport(cfg.RaftAddr, addr, 3, 10*time.Second, os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create raft transport: %v", err)
+	}
+
+	snapshots, err := raft.NewFileSnapshotStore(cfg.DataDir, 2, os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create raft snapshot store: %v", err)
+	}
+
+	logStore, err := raftboltdb.NewBoltStore(filepath.Join(cfg.DataDir, "raft-log.db"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create raft log store: %v", err)
+	}
+
+	r, err := raft.NewRaft(raftConfig, fsm, logStore, logStore, snapshots, transport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start raft: %v", err)
+	}
+
+	rb := &RaftBackend{
+		nodeID:   cfg.NodeID,
+		raftAddr: cfg.RaftAddr,
+		httpAddr: cfg.HTTPAddr,
+		local:    local,
+		fsm:      fsm,
+		raft:     r,
+		peerHTTP: map[string]string{cfg.RaftAddr: cfg.HTTPAddr},
+	}
+
+	if cfg.Join == "" {
+		r.BootstrapCluster(raft.Configuration{
+			Servers: []raft.Server{{ID: raftConfig.LocalID, Address: transport.LocalAddr()}},
+		})
+	} else if err := rb.requestJoin(cfg.Join); err != nil {
+		return nil, fmt.Errorf("failed to join cluster via %s: %v", cfg.Join, err)
+	}
+
+	return rb, nil
+}
+
+// requestJoin asks an existing cluster member (identified by its HTTP
+// address) to add this node as a voter.
+func (rb *RaftBackend) requestJoin(leaderHTTPAddr string) error {
+	body := fmt.Sprintf(`{"node_id":%q,"raft_addr":%q,"http_addr":%q}`, rb.nodeID, rb.raftAddr, rb.httpAddr)
+	resp, err := http.Post("http://"+leaderHTTPAddr+"/cluster/join", "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("join request rejected with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// AddVoter is called by the handler behind POST /cluster/join; only the
+// leader can actually execute it; raft itself enforces this.
+func (rb *RaftBackend) AddVoter(nodeID, raftAddr, httpAddr string) error {
+	f := rb.raft.AddVoter(raft.ServerID(nodeID), raft.ServerAddress(raftAddr), 0, 10*time.Second)
+	if err := f.Error(); err != nil {
+		return err
+	}
+	rb.mu.Lock()
+	rb.peerHTTP[raftAddr] = httpAddr
+	rb.mu.Unlock()
+	return nil
+}
+
+// RemoveServer is called by the handler behind POST /cluster/remove.
+func (rb *RaftBackend) RemoveServer(nodeID string) error {
+	f := rb.raft.RemoveServer(raft.ServerID(nodeID), 0, 10*time.Second)
+	return f.Error()
+}
+
+// Status summarizes cluster/raft health for GET /health and GET /cluster/status.
+type RaftStatus struct {
+	NodeID           string `json:"node_id"`
+	RaftState        string `json:"raft_state"`
+	Leader           string `json:"leader"`
+	LastAppliedIndex uint64 `json:"last_applied_index"`
+}
+
+func (rb *RaftBackend) Status() RaftStatus {
+	leaderAddr, _ := rb.raft.LeaderWithID()
+	return RaftStatus{
+		NodeID:           rb.nodeID,
+		RaftState:        rb.raft.State().String(),
+		Leader:           string(leaderAddr),
+		LastAppliedIndex: rb.raft.AppliedIndex(),
+	}
+}
+
+// LeaderHTTPAddr returns the HTTP address (not Raft address) of the
+// current leader, if known, so non-leader nodes can forward/redirect.
+func (rb *RaftBackend) LeaderHTTPAddr() (string, bool) {
+	leaderAddr, _ := rb.raft.LeaderWithID()
+	if leaderAddr == "" {
+		return "", false
+	}
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	addr, ok := rb.peerHTTP[string(leaderAddr)]
+	return addr, ok
+}
+
+func (rb *RaftBackend) IsLeader() bool {
+	return rb.raft.State() == raft.Leader
+}
+
+// Append replicates the record through Raft consensus. It must only be
+// called on the leader; callers should check IsLeader()/LeaderHTTPAddr()
+// first and redirect non-leader requests to the leader.
+func (rb *RaftBackend) Append(record *SealRecord) (int, error) {
+	if !rb.IsLeader() {
+		leader, _ := rb.LeaderHTTPAddr()
+		return 0, fmt.Errorf("not the leader, current leader is %q", leader)
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(sealCommand{Record: record}); err != nil {
+		return 0, err
+	}
+
+	f := rb.raft.Apply(buf.Bytes(), 5*time.Second)
+	if err := f.Error(); err != nil {
+		return 0, fmt.Errorf("raft apply failed: %v", err)
+	}
+	switch resp := f.Response().(type) {
+	case int:
+		return resp, nil
+	case error:
+		return 0, resp
+	default:
+		return 0, fmt.Errorf("unexpected raft apply response type %T", resp)
+	}
+}
+
+func (rb *RaftBackend) GetByHash(hash string) (*SealRecord, bool, error) {
+	return rb.local.GetByHash(hash)
+}
+
+func (rb *RaftBackend) List(offset, limit int) ([]*SealRecord, error) {
+	return rb.local.List(offset, limit)
+}
+
+func (rb *RaftBackend) Snapshot() ([]byte, error) {
+	return rb.local.Snapshot()
+}
+
+// Restore is not exposed directly on RaftBackend: state is restored via
+// Raft snapshots (sealFSM.Restore) during normal cluster operation.
+func (rb *RaftBackend) Restore(data []byte) error {
+	return fmt.Errorf("restore must go through the raft snapshot mechanism, not a direct call")
+}
+
+func (rb *RaftBackend) Close() error {
+	if f := rb.raft.Shutdown(); f.Error() != nil {
+		return f.Error()
+	}
+	return rb.local.Close()
+}
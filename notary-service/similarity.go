@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ==================== FUZZY HASH COMPARISON ====================
+//
+// The notary never generates ssdeep signatures itself (the hasher
+// service does); it only needs to score how similar two signatures are,
+// to power the /verify near-duplicate fallback and GET /similar.
+
+// FuzzySimilarity compares two ssdeep-style "blocksize:sig1:sig2"
+// signatures and returns a score from 0 (unrelated) to 100 (identical).
+func FuzzySimilarity(a, b string) int {
+	bsA, sig1A, sig2A, okA := parseFuzzyHash(a)
+	bsB, sig1B, sig2B, okB := parseFuzzyHash(b)
+	if !okA || !okB {
+		return 0
+	}
+
+	switch {
+	case bsA == bsB:
+		return scoreSignatures(sig1A, sig1B)
+	case bsA == bsB*2:
+		return scoreSignatures(sig1A, sig2B)
+	case bsB == bsA*2:
+		return scoreSignatures(sig2A, sig1B)
+	default:
+		return 0 // block sizes too far apart to be comparable
+	}
+}
+
+func parseFuzzyHash(s string) (blockSize int, sig1, sig2 string, ok bool) {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) != 3 {
+		return 0, "", "", false
+	}
+	bs, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", "", false
+	}
+	return bs, parts[1], parts[2], true
+}
+
+func scoreSignatures(a, b string) int {
+	if a == "" && b == "" {
+		return 100
+	}
+	dist := levenshtein(a, b)
+	maxLen := len(a)
+	if len(b) > maxLen {
+		maxLen = len(b)
+	}
+	if maxLen == 0 {
+		return 100
+	}
+	score := 100 - (dist*100)/maxLen
+	if score < 0 {
+		score = 0
+	}
+	return score
+}
+
+func levenshtein(a, b string) int {
+	if a == b {
+		return 0
+	}
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			del := prev[j] + 1
+			ins := curr[j-1] + 1
+			sub := prev[j-1] + cost
+			min := del
+			if ins < min {
+				min = ins
+			}
+			if sub < min {
+				min = sub
+			}
+			curr[j] = min
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
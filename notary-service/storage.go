@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+)
+
+// ==================== STORAGE BACKEND ====================
+//
+// Backend decouples the seal store from its persistence strategy. The
+// default is a single-node BoltDB file; RaftBackend wraps a Backend with
+// hashicorp/raft replication so a cluster of notaries agrees on one
+// append-only sequence of seals even across crashes/restarts.
+
+// Backend is anything that can durably hold the ordered sequence of
+// issued seals.
+type Backend interface {
+	// Append adds a new record to the end of the log and returns its
+	// assigned index.
+	Append(record *SealRecord) (index int, err error)
+	// GetByHash looks up a record by its content hash.
+	GetByHash(hash string) (*SealRecord, bool, error)
+	// List returns up to `limit` records starting at `offset`, in
+	// append order.
+	List(offset, limit int) ([]*SealRecord, error)
+	// Snapshot serializes the whole seal set for backup/restore and
+	// Raft log compaction.
+	Snapshot() ([]byte, error)
+	// Restore replaces the backend's contents with a previously taken
+	// Snapshot.
+	Restore(data []byte) error
+	// Close releases any underlying resources (file handles, etc).
+	Close() error
+}
+
+func init() {
+	gob.Register(&SealRecord{})
+}
+
+// encodeSnapshot/decodeSnapshot are shared by every Backend implementation
+// so Raft snapshots and plain Backend.Snapshot() produce the same format.
+func encodeSnapshot(records []*SealRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(records); err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeSnapshot(data []byte) ([]*SealRecord, error) {
+	var records []*SealRecord
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&records); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %v", err)
+	}
+	return records, nil
+}